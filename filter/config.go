@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/naoina/toml"
+	"github.com/naoina/toml/ast"
+)
+
+// UnmarshalConfig decodes table's scalar fields into config, then looks
+// for "measurement" and "field" sub-tables and builds the Filter they
+// describe into config's exported Measurement and Field fields.
+//
+// A sub-table picks its filter kind from whichever of "strings",
+// "pattern" or "include" it contains, e.g.:
+//
+//	[measurement.strings]
+//		equal="cpu"
+//	[field.pattern]
+//		pattern="^(idle|active)"
+func UnmarshalConfig(table *ast.Table, config interface{}) error {
+	if err := toml.UnmarshalTable(table, config); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(config).Elem()
+
+	if err := setFilterField(v, "Measurement", table.Fields["measurement"]); err != nil {
+		return err
+	}
+
+	if err := setFilterField(v, "Field", table.Fields["field"]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setFilterField(v reflect.Value, name string, sub interface{}) error {
+	if sub == nil {
+		return nil
+	}
+
+	f, err := buildFilter(sub)
+	if err != nil {
+		return err
+	}
+
+	field := v.FieldByName(name)
+	if !field.IsValid() || !field.CanSet() {
+		return nil
+	}
+
+	field.Set(reflect.ValueOf(f))
+	return nil
+}
+
+func buildFilter(sub interface{}) (Filter, error) {
+	table, ok := sub.(*ast.Table)
+	if !ok {
+		return nil, fmt.Errorf("filter: expected a table, got %T", sub)
+	}
+
+	if strings, ok := table.Fields["strings"]; ok {
+		config := &StringFilterConfig{}
+		if err := toml.UnmarshalTable(strings.(*ast.Table), config); err != nil {
+			return nil, err
+		}
+		return NewStringFilter(config)
+	}
+
+	if pattern, ok := table.Fields["pattern"]; ok {
+		config := &PatternFilterConfig{}
+		if err := toml.UnmarshalTable(pattern.(*ast.Table), config); err != nil {
+			return nil, err
+		}
+		if config.RawBytes {
+			return NewRawPatternFilter(config.Pattern)
+		}
+		return NewPatternFilter(config.Pattern)
+	}
+
+	if include, ok := table.Fields["include"]; ok {
+		config := &IncludeFilterConfig{}
+		if err := toml.UnmarshalTable(include.(*ast.Table), config); err != nil {
+			return nil, err
+		}
+		return NewIncludeFilter(config.Values), nil
+	}
+
+	return nil, fmt.Errorf("filter: table does not configure a known filter (strings, pattern or include)")
+}
+
+// PatternFilterConfig configures a PatternFilter from TOML.
+type PatternFilterConfig struct {
+	Pattern string `toml:"pattern"`
+
+	// RawBytes, when set, matches against the raw, still-escaped bytes
+	// read from a shard instead of the unescaped form callers normally
+	// compare against.
+	RawBytes bool `toml:"rawBytes"`
+}
+
+// IncludeFilterConfig configures an IncludeFilter from TOML.
+type IncludeFilterConfig struct {
+	Values []string `toml:"values"`
+}
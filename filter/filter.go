@@ -0,0 +1,21 @@
+// Package filter provides the matching primitives rules use to decide
+// which measurements and fields they apply to.
+package filter
+
+// Filter reports whether a measurement or field name read from a shard
+// matches some criteria.
+type Filter interface {
+	// Match reports whether s matches the filter.
+	Match(s string) bool
+
+	// MatchBytes is Match for a name already held as a byte slice, so
+	// callers on the hot rewrite path don't have to allocate a string.
+	MatchBytes(b []byte) bool
+}
+
+// RawByteser is implemented by filters configured to match against the
+// raw, still line-protocol-escaped bytes read from a shard, instead of
+// the unescaped form callers normally hand them.
+type RawByteser interface {
+	RawBytes() bool
+}
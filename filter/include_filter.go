@@ -0,0 +1,24 @@
+package filter
+
+// IncludeFilter matches a name against a fixed set of values.
+type IncludeFilter struct {
+	values map[string]struct{}
+}
+
+// NewIncludeFilter builds an IncludeFilter matching any of values.
+func NewIncludeFilter(values []string) *IncludeFilter {
+	f := &IncludeFilter{values: make(map[string]struct{}, len(values))}
+	for _, v := range values {
+		f.values[v] = struct{}{}
+	}
+	return f
+}
+
+func (f *IncludeFilter) Match(s string) bool {
+	_, ok := f.values[s]
+	return ok
+}
+
+func (f *IncludeFilter) MatchBytes(b []byte) bool {
+	return f.Match(string(b))
+}
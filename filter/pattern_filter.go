@@ -0,0 +1,46 @@
+package filter
+
+import "regexp"
+
+// PatternFilter matches a name against a regular expression.
+type PatternFilter struct {
+	Pattern *regexp.Regexp
+
+	rawBytes bool
+}
+
+// NewPatternFilter compiles pattern into a PatternFilter.
+func NewPatternFilter(pattern string) (*PatternFilter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PatternFilter{Pattern: re}, nil
+}
+
+// NewRawPatternFilter is NewPatternFilter for a filter that should match
+// against raw, still-escaped bytes instead of the unescaped form callers
+// normally compare against.
+func NewRawPatternFilter(pattern string) (*PatternFilter, error) {
+	f, err := NewPatternFilter(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	f.rawBytes = true
+	return f, nil
+}
+
+// RawBytes reports whether f matches against raw, still-escaped bytes.
+func (f *PatternFilter) RawBytes() bool {
+	return f.rawBytes
+}
+
+func (f *PatternFilter) Match(s string) bool {
+	return f.Pattern.MatchString(s)
+}
+
+func (f *PatternFilter) MatchBytes(b []byte) bool {
+	return f.Pattern.Match(b)
+}
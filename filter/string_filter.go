@@ -0,0 +1,67 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEmptyStringFilter is returned when a StringFilterConfig does not set
+// any of Equal, HasPrefix or HasSuffix.
+var ErrEmptyStringFilter = errors.New("filter: empty string filter")
+
+// StringFilterConfig configures a StringFilter from TOML.
+type StringFilterConfig struct {
+	Equal     string `toml:"equal"`
+	HasPrefix string `toml:"hasPrefix"`
+	HasSuffix string `toml:"hasSuffix"`
+
+	// RawBytes, when set, matches against the raw, still-escaped bytes
+	// read from a shard instead of the unescaped form callers normally
+	// compare against.
+	RawBytes bool `toml:"rawBytes"`
+}
+
+// StringFilter matches a name against an exact value, a prefix or a
+// suffix.
+type StringFilter struct {
+	Equal     string
+	HasPrefix string
+	HasSuffix string
+
+	rawBytes bool
+}
+
+// NewStringFilter builds a StringFilter from config.
+func NewStringFilter(config *StringFilterConfig) (*StringFilter, error) {
+	if config.Equal == "" && config.HasPrefix == "" && config.HasSuffix == "" {
+		return nil, ErrEmptyStringFilter
+	}
+
+	return &StringFilter{
+		Equal:     config.Equal,
+		HasPrefix: config.HasPrefix,
+		HasSuffix: config.HasSuffix,
+		rawBytes:  config.RawBytes,
+	}, nil
+}
+
+// RawBytes reports whether f matches against raw, still-escaped bytes.
+func (f *StringFilter) RawBytes() bool {
+	return f.rawBytes
+}
+
+func (f *StringFilter) Match(s string) bool {
+	switch {
+	case f.Equal != "":
+		return s == f.Equal
+	case f.HasPrefix != "":
+		return strings.HasPrefix(s, f.HasPrefix)
+	case f.HasSuffix != "":
+		return strings.HasSuffix(s, f.HasSuffix)
+	}
+	return false
+}
+
+func (f *StringFilter) MatchBytes(b []byte) bool {
+	return f.Match(string(b))
+}
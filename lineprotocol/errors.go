@@ -0,0 +1,19 @@
+package lineprotocol
+
+import "fmt"
+
+// ParseError is returned by Parser.Parse when a line is malformed. Offset
+// is the byte offset into the buffer passed to Parse at which the error
+// was detected.
+type ParseError struct {
+	Offset int64
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("lineprotocol: %s (at byte offset %d)", e.Reason, e.Offset)
+}
+
+func newParseError(offset int64, reason string) *ParseError {
+	return &ParseError{Offset: offset, Reason: reason}
+}
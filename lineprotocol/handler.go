@@ -0,0 +1,54 @@
+// Package lineprotocol implements an allocation-light, streaming parser
+// for the InfluxDB line protocol.
+//
+// The Parser drives a Handler with byte slices that point directly into
+// the buffer it was given, so a caller that wants to keep a token around
+// past EmitPoint must copy it itself. The one token the Parser copies is
+// a quoted string value containing `\"`, which it unescapes into a newly
+// allocated slice.
+package lineprotocol
+
+// Handler receives the tokens a Parser recognizes as it scans a line
+// protocol buffer. Key and value slices point into the buffer passed to
+// Parser.Parse and are only valid until the next Handler call.
+type Handler interface {
+	// SetMeasurement is called once per line with the (still escaped)
+	// measurement name.
+	SetMeasurement(name []byte)
+
+	// AddTag is called once per tag, in the order they appear on the
+	// line.
+	AddTag(key, value []byte)
+
+	// AddInt is called for a field written with an "i" suffix, e.g.
+	// count=12i.
+	AddInt(key, value []byte)
+
+	// AddUint is called for a field written with a "u" suffix, e.g.
+	// count=12u.
+	AddUint(key, value []byte)
+
+	// AddFloat is called for a bare numeric field, e.g. value=12.8.
+	AddFloat(key, value []byte)
+
+	// AddString is called for a double-quoted field; value is the
+	// content between the quotes, with `\"` already unescaped to `"`.
+	// When such an escape was present, value is a new slice rather than
+	// one pointing into the buffer.
+	AddString(key, value []byte)
+
+	// AddBool is called for a boolean literal field (t, T, true, True,
+	// TRUE, f, F, false, False, FALSE).
+	AddBool(key, value []byte)
+
+	// SetTimestamp is called with the line's timestamp, converted to
+	// Unix nanoseconds according to the parser's configured precision.
+	// It is called at most once per line, and not at all if the line
+	// omits a timestamp.
+	SetTimestamp(ns int64)
+
+	// EmitPoint is called once a line has been fully parsed. This is the
+	// only point at which a handler should allocate: every token it
+	// wants to keep must be copied before returning.
+	EmitPoint()
+}
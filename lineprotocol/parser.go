@@ -0,0 +1,222 @@
+package lineprotocol
+
+import "strconv"
+
+// Parser is a streaming, allocation-light line protocol parser. It scans
+// a buffer byte by byte, handing the handler slices that reference the
+// buffer directly; see Handler for the allocation contract.
+type Parser struct {
+	handler   Handler
+	precision Precision
+}
+
+// NewParser returns a Parser that drives handler, interpreting line
+// timestamps at the given precision.
+func NewParser(handler Handler, precision Precision) *Parser {
+	return &Parser{handler: handler, precision: precision}
+}
+
+// Parse scans every line in data, calling the parser's handler for each
+// one. Blank lines and lines starting with '#' (comments) are skipped.
+func (p *Parser) Parse(data []byte) error {
+	offset := 0
+
+	for offset < len(data) {
+		switch data[offset] {
+		case '\n':
+			offset++
+			continue
+		case '#':
+			_, end := scanToken(data, offset, isNewline)
+			if end < len(data) {
+				end++
+			}
+			offset = end
+			continue
+		}
+
+		n, err := p.parseLine(data[offset:], int64(offset))
+		if err != nil {
+			return err
+		}
+		offset += n
+	}
+
+	return nil
+}
+
+// parseLine parses a single line starting at data[0], which is assumed to
+// sit at absolute offset base within the buffer originally passed to
+// Parse. It returns the number of bytes consumed, including the
+// terminating newline if one was present.
+func (p *Parser) parseLine(data []byte, base int64) (int, error) {
+	pos := 0
+
+	name, next := scanToken(data, pos, isTagOrFieldSep)
+	if len(name) == 0 {
+		return next, newParseError(base, "empty measurement")
+	}
+	p.handler.SetMeasurement(name)
+	pos = next
+
+	for pos < len(data) && data[pos] == ',' {
+		pos++
+
+		key, p1 := scanToken(data, pos, isEqualsOrNewline)
+		if p1 >= len(data) || data[p1] != '=' {
+			return p1, newParseError(base+int64(p1), "expected '=' after tag key")
+		}
+		pos = p1 + 1
+
+		value, p2 := scanToken(data, pos, isTagOrFieldSep)
+		p.handler.AddTag(key, value)
+		pos = p2
+	}
+
+	if pos >= len(data) || data[pos] != ' ' {
+		return pos, newParseError(base+int64(pos), "expected a space before the field set")
+	}
+	pos++
+
+	if err := p.parseFields(data, &pos, base); err != nil {
+		return pos, err
+	}
+
+	if pos < len(data) && data[pos] == ' ' {
+		pos++
+
+		tsTok, p3 := scanToken(data, pos, isNewline)
+		if len(tsTok) > 0 {
+			ts, err := strconv.ParseInt(string(tsTok), 10, 64)
+			if err != nil {
+				return p3, newParseError(base+int64(pos), "invalid timestamp")
+			}
+			p.handler.SetTimestamp(ts * p.precision.multiplier())
+		}
+		pos = p3
+	}
+
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+
+	p.handler.EmitPoint()
+	return pos, nil
+}
+
+func (p *Parser) parseFields(data []byte, pos *int, base int64) error {
+	for {
+		key, p1 := scanToken(data, *pos, isEqualsOrNewline)
+		if p1 >= len(data) || data[p1] != '=' {
+			return newParseError(base+int64(p1), "expected '=' after field key")
+		}
+		*pos = p1 + 1
+
+		if *pos < len(data) && data[*pos] == '"' {
+			*pos++
+			value, p2 := scanToken(data, *pos, isQuote)
+			if p2 >= len(data) {
+				return newParseError(base+int64(p2), "unterminated quoted string field")
+			}
+			p.handler.AddString(key, unescapeQuoted(value))
+			*pos = p2 + 1
+		} else {
+			value, p2 := scanToken(data, *pos, isFieldSep)
+			if err := p.emitTypedField(key, value, base+int64(*pos)); err != nil {
+				return err
+			}
+			*pos = p2
+		}
+
+		if *pos < len(data) && data[*pos] == ',' {
+			*pos++
+			continue
+		}
+		return nil
+	}
+}
+
+func (p *Parser) emitTypedField(key, value []byte, offset int64) error {
+	if len(value) == 0 {
+		return newParseError(offset, "empty field value")
+	}
+
+	switch last := value[len(value)-1]; {
+	case last == 'i':
+		p.handler.AddInt(key, value[:len(value)-1])
+	case last == 'u':
+		p.handler.AddUint(key, value[:len(value)-1])
+	case isBoolLiteral(value):
+		p.handler.AddBool(key, value)
+	default:
+		p.handler.AddFloat(key, value)
+	}
+
+	return nil
+}
+
+func isBoolLiteral(value []byte) bool {
+	switch string(value) {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return true
+	default:
+		return false
+	}
+}
+
+func isTagOrFieldSep(b byte) bool   { return b == ',' || b == ' ' || b == '\n' }
+func isFieldSep(b byte) bool        { return b == ',' || b == ' ' || b == '\n' }
+func isEqualsOrNewline(b byte) bool { return b == '=' || b == '\n' }
+func isQuote(b byte) bool           { return b == '"' }
+func isNewline(b byte) bool         { return b == '\n' }
+
+// scanToken advances from pos until it finds a byte matching stop that is
+// not preceded by an unescaped backslash, returning the slice of data
+// consumed (escapes left untouched) and the index stopped at.
+func scanToken(data []byte, pos int, stop func(byte) bool) (token []byte, end int) {
+	start := pos
+
+	for pos < len(data) {
+		b := data[pos]
+
+		if b == '\\' && pos+1 < len(data) {
+			pos += 2
+			continue
+		}
+
+		if stop(b) {
+			return data[start:pos], pos
+		}
+
+		pos++
+	}
+
+	return data[start:pos], pos
+}
+
+// unescapeQuoted turns `\"` into `"` inside a quoted string field value.
+// It returns value unmodified if it contains no backslash, so the common
+// case allocates nothing.
+func unescapeQuoted(value []byte) []byte {
+	hasEscape := false
+	for _, b := range value {
+		if b == '\\' {
+			hasEscape = true
+			break
+		}
+	}
+	if !hasEscape {
+		return value
+	}
+
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && value[i+1] == '"' {
+			out = append(out, '"')
+			i++
+			continue
+		}
+		out = append(out, value[i])
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package lineprotocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedPoint struct {
+	measurement string
+	tags        map[string]string
+	ints        map[string]string
+	uints       map[string]string
+	floats      map[string]string
+	strings     map[string]string
+	bools       map[string]string
+	timestamp   int64
+}
+
+type recordingHandler struct {
+	points  []*recordedPoint
+	current *recordedPoint
+}
+
+func (h *recordingHandler) start() {
+	h.current = &recordedPoint{
+		tags:    map[string]string{},
+		ints:    map[string]string{},
+		uints:   map[string]string{},
+		floats:  map[string]string{},
+		strings: map[string]string{},
+		bools:   map[string]string{},
+	}
+}
+
+func (h *recordingHandler) SetMeasurement(name []byte) {
+	h.start()
+	h.current.measurement = string(name)
+}
+
+func (h *recordingHandler) AddTag(key, value []byte) {
+	h.current.tags[string(key)] = string(value)
+}
+
+func (h *recordingHandler) AddInt(key, value []byte) {
+	h.current.ints[string(key)] = string(value)
+}
+
+func (h *recordingHandler) AddUint(key, value []byte) {
+	h.current.uints[string(key)] = string(value)
+}
+
+func (h *recordingHandler) AddFloat(key, value []byte) {
+	h.current.floats[string(key)] = string(value)
+}
+
+func (h *recordingHandler) AddString(key, value []byte) {
+	h.current.strings[string(key)] = string(value)
+}
+
+func (h *recordingHandler) AddBool(key, value []byte) {
+	h.current.bools[string(key)] = string(value)
+}
+
+func (h *recordingHandler) SetTimestamp(ns int64) {
+	h.current.timestamp = ns
+}
+
+func (h *recordingHandler) EmitPoint() {
+	h.points = append(h.points, h.current)
+	h.current = nil
+}
+
+func TestParser_ShouldParseFieldsOfEveryType(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte(`cpu,host=server01 idle=12i,ratio=0.97,up=true,state="ok" 1577836800000000000` + "\n"))
+	assert.NoError(t, err)
+	assert.Len(t, handler.points, 1)
+
+	point := handler.points[0]
+	assert.Equal(t, "cpu", point.measurement)
+	assert.Equal(t, "server01", point.tags["host"])
+	assert.Equal(t, "12", point.ints["idle"])
+	assert.Equal(t, "0.97", point.floats["ratio"])
+	assert.Equal(t, "true", point.bools["up"])
+	assert.Equal(t, "ok", point.strings["state"])
+	assert.Equal(t, int64(1577836800000000000), point.timestamp)
+}
+
+func TestParser_ShouldParseUnsignedField(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte("disk_free,device=sda free=512u\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "512", handler.points[0].uints["free"])
+}
+
+func TestParser_ShouldHandleEscapesInKeysAndTagValues(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte(`cpu\ load,host=my\ host value\=1=12i` + "\n"))
+	assert.NoError(t, err)
+
+	point := handler.points[0]
+	assert.Equal(t, `cpu\ load`, point.measurement)
+	assert.Equal(t, `my\ host`, point.tags["host"])
+	assert.Equal(t, "12", point.ints[`value\=1`])
+}
+
+func TestParser_ShouldUnescapeQuotedStringField(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte(`event message="she said \"hi\"" ` + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, `she said "hi"`, handler.points[0].strings["message"])
+}
+
+func TestParser_ShouldParseMultipleLines(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte("cpu value=1i\nmem value=2i\n"))
+	assert.NoError(t, err)
+	assert.Len(t, handler.points, 2)
+	assert.Equal(t, "cpu", handler.points[0].measurement)
+	assert.Equal(t, "mem", handler.points[1].measurement)
+}
+
+func TestParser_ShouldConvertTimestampPrecision(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Millisecond)
+
+	err := p.Parse([]byte("cpu value=1i 1500000000000\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500000000000000000), handler.points[0].timestamp)
+}
+
+func TestParser_ShouldSkipBlankLinesAndComments(t *testing.T) {
+	handler := &recordingHandler{}
+	p := NewParser(handler, Nanosecond)
+
+	err := p.Parse([]byte("\n# a comment\ncpu value=1i\n"))
+	assert.NoError(t, err)
+	assert.Len(t, handler.points, 1)
+}
+
+func TestParser_ShouldReturnTypedErrorWithOffset(t *testing.T) {
+	data := []struct {
+		name string
+		line string
+	}{
+		{"missing field set", "cpu\n"},
+		{"missing '=' after field key", "cpu value\n"},
+		{"unterminated quoted string", `cpu state="ok` + "\n"},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			handler := &recordingHandler{}
+			p := NewParser(handler, Nanosecond)
+
+			err := p.Parse([]byte(d.line))
+			assert.Error(t, err)
+
+			parseErr, ok := err.(*ParseError)
+			assert.True(t, ok)
+			assert.True(t, parseErr.Offset >= 0)
+		})
+	}
+}
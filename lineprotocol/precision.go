@@ -0,0 +1,46 @@
+package lineprotocol
+
+import "fmt"
+
+// Precision is the unit a line's timestamp is expressed in.
+type Precision int
+
+const (
+	Nanosecond Precision = iota
+	Microsecond
+	Millisecond
+	Second
+)
+
+// multiplier is the number of nanoseconds in one unit of p.
+func (p Precision) multiplier() int64 {
+	switch p {
+	case Nanosecond:
+		return 1
+	case Microsecond:
+		return 1e3
+	case Millisecond:
+		return 1e6
+	case Second:
+		return 1e9
+	default:
+		return 1
+	}
+}
+
+// ParsePrecision maps the TOML precision strings ("ns", "us", "ms", "s")
+// onto a Precision.
+func ParsePrecision(s string) (Precision, error) {
+	switch s {
+	case "", "ns":
+		return Nanosecond, nil
+	case "us":
+		return Microsecond, nil
+	case "ms":
+		return Millisecond, nil
+	case "s":
+		return Second, nil
+	default:
+		return Nanosecond, fmt.Errorf("lineprotocol: unknown precision %q", s)
+	}
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/oktal/infix/filter"
+)
+
+// unescapeMeasurement undoes the line-protocol escaping of a measurement
+// name: `\,` -> `,` and `\ ` -> ` `.
+func unescapeMeasurement(b []byte) []byte {
+	return unescape(b, ',', ' ')
+}
+
+// unescapeKey undoes the line-protocol escaping of a tag or field key:
+// `\,` -> `,`, `\ ` -> ` ` and `\=` -> `=`.
+func unescapeKey(b []byte) []byte {
+	return unescape(b, ',', ' ', '=')
+}
+
+// unescape turns `\c` into `c` for every c in specials, leaving any other
+// backslash untouched. It returns b unmodified, without allocating, when
+// b contains no backslash at all.
+func unescape(b []byte, specials ...byte) []byte {
+	hasBackslash := false
+	for _, c := range b {
+		if c == '\\' {
+			hasBackslash = true
+			break
+		}
+	}
+	if !hasBackslash {
+		return b
+	}
+
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) && isOneOf(b[i+1], specials) {
+			out = append(out, b[i+1])
+			i++
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
+
+// escapeKey is the inverse of unescapeKey: it backslash-escapes `,`, ` `
+// and `=` so name is safe to use as a tag/field key or value inside a
+// composite key again.
+func escapeKey(name string) string {
+	hasSpecial := false
+	for i := 0; i < len(name); i++ {
+		if isOneOf(name[i], []byte{',', ' ', '='}) {
+			hasSpecial = true
+			break
+		}
+	}
+	if !hasSpecial {
+		return name
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if isOneOf(name[i], []byte{',', ' ', '='}) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+func isOneOf(b byte, values []byte) bool {
+	for _, v := range values {
+		if b == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMeasurementAndField reports whether measurement and field, both
+// read raw (still escaped) from a shard's composite key, match
+// measurementFilter and fieldFilter. A filter sees the unescaped form
+// unless it opts into matching raw bytes via filter.RawBytes.
+func matchMeasurementAndField(measurementFilter, fieldFilter filter.Filter, measurement, field []byte) bool {
+	return matchRaw(measurementFilter, measurement, unescapeMeasurement) &&
+		matchRaw(fieldFilter, field, unescapeKey)
+}
+
+func matchRaw(f filter.Filter, raw []byte, unescapeFn func([]byte) []byte) bool {
+	if rb, ok := f.(filter.RawByteser); ok && rb.RawBytes() {
+		return f.MatchBytes(raw)
+	}
+	return f.MatchBytes(unescapeFn(raw))
+}
@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnescapeMeasurement(t *testing.T) {
+	data := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"no escapes", "cpu", "cpu"},
+		{"escaped space", `cpu\ load`, "cpu load"},
+		{"escaped comma", `cpu\,gauge`, "cpu,gauge"},
+		{"leaves equals untouched", `cpu\=load`, `cpu\=load`},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			assert.Equal(t, d.expected, string(unescapeMeasurement([]byte(d.in))))
+		})
+	}
+}
+
+func TestUnescapeKey(t *testing.T) {
+	data := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"no escapes", "value", "value"},
+		{"escaped equals", `value\=1`, "value=1"},
+		{"escaped space", `idle\ avg`, "idle avg"},
+		{"escaped comma", `idle\,avg`, "idle,avg"},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			assert.Equal(t, d.expected, string(unescapeKey([]byte(d.in))))
+		})
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	data := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"no specials", "value", "value"},
+		{"equals", "value=1", `value\=1`},
+		{"space", "idle avg", `idle\ avg`},
+		{"comma", "idle,avg", `idle\,avg`},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			assert.Equal(t, d.expected, escapeKey(d.in))
+		})
+	}
+}
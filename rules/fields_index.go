@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"sync"
+
+	"github.com/influxdata/influxql"
+)
+
+// Field describes the type of a single field within a measurement, as
+// tracked by a shard's fields.idx.
+type Field struct {
+	Name string
+	Type influxql.DataType
+}
+
+// MeasurementFields is the set of fields known for a single measurement.
+type MeasurementFields struct {
+	mu     sync.RWMutex
+	fields map[string]*Field
+}
+
+func newMeasurementFields() *MeasurementFields {
+	return &MeasurementFields{fields: make(map[string]*Field)}
+}
+
+// FieldBytes returns the field named name, or nil if it isn't known.
+func (m *MeasurementFields) FieldBytes(name []byte) *Field {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.fields[string(name)]
+}
+
+// CreateFieldIfNotExists registers a field with the given type if it is
+// not already known, returning the existing field otherwise.
+func (m *MeasurementFields) CreateFieldIfNotExists(name string, typ influxql.DataType) *Field {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.fields[name]; ok {
+		return f
+	}
+
+	f := &Field{Name: name, Type: typ}
+	m.fields[name] = f
+	return f
+}
+
+// SetType updates the type recorded for field name, if it is known.
+func (m *MeasurementFields) SetType(name string, typ influxql.DataType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.fields[name]; ok {
+		f.Type = typ
+	}
+}
+
+// Rename moves the field known as oldName to newName, keeping its type.
+func (m *MeasurementFields) Rename(oldName, newName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.fields[oldName]
+	if !ok {
+		return
+	}
+
+	delete(m.fields, oldName)
+	f.Name = newName
+	m.fields[newName] = f
+}
+
+// FieldsIndex tracks the known fields of every measurement in a shard, so
+// rules can look up and update field types and names as they rewrite it.
+type FieldsIndex struct {
+	mu           sync.RWMutex
+	measurements map[string]*MeasurementFields
+}
+
+// NewFieldsIndex returns an empty FieldsIndex.
+func NewFieldsIndex() *FieldsIndex {
+	return &FieldsIndex{measurements: make(map[string]*MeasurementFields)}
+}
+
+// Fields returns the fields known for measurement, or nil.
+func (idx *FieldsIndex) Fields(measurement []byte) *MeasurementFields {
+	return idx.FieldsByString(string(measurement))
+}
+
+// FieldsByString is Fields for a measurement name already held as a
+// string.
+func (idx *FieldsIndex) FieldsByString(measurement string) *MeasurementFields {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.measurements[measurement]
+}
+
+// CreateFieldsIfNotExists returns the MeasurementFields for measurement,
+// creating an empty one if it doesn't exist yet.
+func (idx *FieldsIndex) CreateFieldsIfNotExists(measurement string) *MeasurementFields {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	mf, ok := idx.measurements[measurement]
+	if !ok {
+		mf = newMeasurementFields()
+		idx.measurements[measurement] = mf
+	}
+	return mf
+}
@@ -0,0 +1,410 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/influxdb/tsdb/engine/tsm1"
+
+	"github.com/oktal/infix/filter"
+	"github.com/oktal/infix/lineprotocol"
+)
+
+// ErrMissingFiles is returned when an InsertLineProtocolRuleConfig does
+// not set Files.
+var ErrMissingFiles = errors.New("rules: missing files")
+
+// ErrMissingTimestamp is returned when a line protocol point has no
+// timestamp. InsertLineProtocol refuses to default it to the Unix epoch,
+// since that would silently collapse every timestamp-less point for a
+// series/field onto a single value.
+var ErrMissingTimestamp = errors.New("rules: line protocol point is missing a timestamp")
+
+// duplicateKeyPolicy controls what InsertLineProtocol does when the
+// source already has a value for a series/field/timestamp it is about to
+// insert.
+type duplicateKeyPolicy int
+
+const (
+	// duplicateKeyOverwrite replaces the existing value.
+	duplicateKeyOverwrite duplicateKeyPolicy = iota
+	// duplicateKeySkip keeps the existing value.
+	duplicateKeySkip
+)
+
+func duplicateKeyPolicyFromString(s string) (duplicateKeyPolicy, error) {
+	switch s {
+	case "", "overwrite":
+		return duplicateKeyOverwrite, nil
+	case "skip":
+		return duplicateKeySkip, nil
+	default:
+		return duplicateKeyOverwrite, fmt.Errorf("rules: unknown onDuplicateKey policy %q", s)
+	}
+}
+
+// ShardWriter is the subset of the rewrite engine's writer path that a
+// rule needs to persist brand new tsm1.Values alongside the blocks it is
+// rewriting.
+type ShardWriter interface {
+	WriteValues(key []byte, values []tsm1.Value) error
+}
+
+// InsertLineProtocolRuleConfig configures a rule that merges points read
+// from a line protocol source into the shards infix rewrites.
+type InsertLineProtocolRuleConfig struct {
+	// Files is a glob of line protocol files to read, e.g.
+	// "/var/lib/infix/import/*.lp".
+	Files string `toml:"files"`
+
+	// Tags are merged into every point that doesn't already set them.
+	Tags map[string]string `toml:"tags"`
+
+	// Precision is the unit line timestamps are expressed in: "ns"
+	// (the default), "us", "ms" or "s".
+	Precision string `toml:"precision"`
+
+	// OnDuplicateKey selects what happens when a point being inserted
+	// already has a value at the same series/field/timestamp:
+	// "overwrite" (the default) or "skip".
+	OnDuplicateKey string `toml:"onDuplicateKey"`
+
+	Measurement filter.Filter
+	Field       filter.Filter
+}
+
+func (c *InsertLineProtocolRuleConfig) Sample() string {
+	return `
+		files="/var/lib/infix/import/*.lp"
+		precision="ns"
+		onDuplicateKey="overwrite"
+		[tags]
+			source="import"
+		[measurement.strings]
+			equal="cpu"
+		[field.pattern]
+			pattern=".*"
+	`
+}
+
+func (c *InsertLineProtocolRuleConfig) Build() (Rule, error) {
+	if c.Files == "" {
+		return nil, ErrMissingFiles
+	}
+
+	if c.Measurement == nil {
+		return nil, ErrMissingMeasurementFilter
+	}
+
+	if c.Field == nil {
+		return nil, ErrMissingFieldFilter
+	}
+
+	precision, err := lineprotocol.ParsePrecision(c.Precision)
+	if err != nil {
+		return nil, err
+	}
+
+	onDuplicateKey, err := duplicateKeyPolicyFromString(c.OnDuplicateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInsertLineProtocol(c.Files, c.Tags, precision, onDuplicateKey, c.Measurement, c.Field), nil
+}
+
+type fieldKind int
+
+const (
+	fieldInt fieldKind = iota
+	fieldUint
+	fieldFloat
+	fieldString
+	fieldBool
+)
+
+type pendingField struct {
+	name  string
+	value []byte
+	kind  fieldKind
+}
+
+// InsertLineProtocol reads points from a glob of line protocol files and
+// merges the ones matching measurement and field into the shard it is
+// rewriting, alongside the transformed existing blocks.
+//
+// It implements lineprotocol.Handler itself: StartShard drives a
+// lineprotocol.Parser over every matched file, and InsertLineProtocol
+// buckets the resulting points by composite key as they're emitted.
+// EndShard hands the buckets to the shard's writer.
+type InsertLineProtocol struct {
+	glob           string
+	defaultTags    map[string]string
+	precision      lineprotocol.Precision
+	onDuplicateKey duplicateKeyPolicy
+
+	measurement filter.Filter
+	field       filter.Filter
+
+	check bool
+	shard *Shard
+
+	pending map[string][]tsm1.Value
+	err     error
+
+	curMeasurement  []byte
+	curTags         map[string]string
+	curFields       []pendingField
+	curTimestamp    int64
+	curHasTimestamp bool
+}
+
+// NewInsertLineProtocol returns an InsertLineProtocol rule reading points
+// from the files matched by glob.
+func NewInsertLineProtocol(glob string, defaultTags map[string]string, precision lineprotocol.Precision, onDuplicateKey duplicateKeyPolicy, measurement, field filter.Filter) *InsertLineProtocol {
+	return &InsertLineProtocol{
+		glob:           glob,
+		defaultTags:    defaultTags,
+		precision:      precision,
+		onDuplicateKey: onDuplicateKey,
+		measurement:    measurement,
+		field:          field,
+	}
+}
+
+func (r *InsertLineProtocol) Start() {}
+
+func (r *InsertLineProtocol) StartShard(shard *Shard) {
+	r.shard = shard
+	r.pending = make(map[string][]tsm1.Value)
+	r.err = nil
+
+	matches, err := filepath.Glob(r.glob)
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	parser := lineprotocol.NewParser(r, r.precision)
+
+	for _, path := range matches {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			r.err = err
+			return
+		}
+
+		if err := parser.Parse(data); err != nil {
+			r.err = err
+			return
+		}
+	}
+}
+
+func (r *InsertLineProtocol) CheckMode(check bool) {
+	r.check = check
+}
+
+// Apply leaves existing values untouched: InsertLineProtocol only adds
+// new points, gathered while parsing its source during StartShard.
+func (r *InsertLineProtocol) Apply(key []byte, values []tsm1.Value) ([]byte, []tsm1.Value, error) {
+	return key, values, nil
+}
+
+func (r *InsertLineProtocol) EndShard() error {
+	defer func() {
+		r.shard = nil
+		r.pending = nil
+	}()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if r.check || r.shard == nil || r.shard.Writer == nil {
+		return nil
+	}
+
+	for key, values := range r.pending {
+		if err := r.shard.Writer.WriteValues([]byte(key), values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *InsertLineProtocol) End() {}
+
+// SetMeasurement implements lineprotocol.Handler.
+func (r *InsertLineProtocol) SetMeasurement(name []byte) {
+	r.curMeasurement = append(r.curMeasurement[:0], name...)
+	r.curTags = make(map[string]string)
+	r.curFields = r.curFields[:0]
+	r.curTimestamp = 0
+	r.curHasTimestamp = false
+}
+
+// AddTag implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddTag(key, value []byte) {
+	r.curTags[string(key)] = string(value)
+}
+
+// AddInt implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddInt(key, value []byte) { r.addField(key, value, fieldInt) }
+
+// AddUint implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddUint(key, value []byte) { r.addField(key, value, fieldUint) }
+
+// AddFloat implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddFloat(key, value []byte) { r.addField(key, value, fieldFloat) }
+
+// AddString implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddString(key, value []byte) { r.addField(key, value, fieldString) }
+
+// AddBool implements lineprotocol.Handler.
+func (r *InsertLineProtocol) AddBool(key, value []byte) { r.addField(key, value, fieldBool) }
+
+func (r *InsertLineProtocol) addField(key, value []byte, kind fieldKind) {
+	r.curFields = append(r.curFields, pendingField{
+		name:  string(key),
+		value: append([]byte(nil), value...),
+		kind:  kind,
+	})
+}
+
+// SetTimestamp implements lineprotocol.Handler.
+func (r *InsertLineProtocol) SetTimestamp(ns int64) {
+	r.curTimestamp = ns
+	r.curHasTimestamp = true
+}
+
+// EmitPoint implements lineprotocol.Handler: it filters the current
+// point's fields and buckets the matching ones by composite key. Filters
+// see the unescaped measurement/field name, matching the escape handling
+// used by UpdateFieldType and RenameField.
+//
+// A point without a timestamp is rejected with ErrMissingTimestamp rather
+// than defaulted to the Unix epoch, since every timestamp-less point for
+// a given series/field would otherwise silently collapse onto the same
+// ts=0 value.
+func (r *InsertLineProtocol) EmitPoint() {
+	measurement := r.curMeasurement
+
+	if matchRaw(r.measurement, measurement, unescapeMeasurement) {
+		if !r.curHasTimestamp {
+			r.err = ErrMissingTimestamp
+			r.resetCurrentPoint()
+			return
+		}
+
+		for k, v := range r.defaultTags {
+			if _, ok := r.curTags[k]; !ok {
+				r.curTags[k] = v
+			}
+		}
+
+		seriesKey := buildSeriesKey(measurement, r.curTags)
+
+		for _, f := range r.curFields {
+			if !matchRaw(r.field, []byte(f.name), unescapeKey) {
+				continue
+			}
+
+			value, err := convertFieldValue(f, r.curTimestamp)
+			if err != nil {
+				r.err = err
+				continue
+			}
+
+			key := string(tsm1.SeriesFieldKeyBytes(string(seriesKey), f.name))
+
+			if i := indexOfTimestamp(r.pending[key], r.curTimestamp); i >= 0 {
+				if r.onDuplicateKey == duplicateKeyOverwrite {
+					r.pending[key][i] = value
+				}
+				continue
+			}
+
+			r.pending[key] = append(r.pending[key], value)
+		}
+	}
+
+	r.resetCurrentPoint()
+}
+
+func (r *InsertLineProtocol) resetCurrentPoint() {
+	r.curMeasurement = nil
+	r.curTags = nil
+	r.curFields = nil
+	r.curTimestamp = 0
+	r.curHasTimestamp = false
+}
+
+func convertFieldValue(f pendingField, ts int64) (tsm1.Value, error) {
+	switch f.kind {
+	case fieldInt:
+		i, err := strconv.ParseInt(string(f.value), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewIntegerValue(ts, i), nil
+	case fieldUint:
+		u, err := strconv.ParseUint(string(f.value), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewUnsignedValue(ts, u), nil
+	case fieldFloat:
+		v, err := strconv.ParseFloat(string(f.value), 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewFloatValue(ts, v), nil
+	case fieldBool:
+		b, err := strconv.ParseBool(string(f.value))
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewBooleanValue(ts, b), nil
+	case fieldString:
+		return tsm1.NewStringValue(ts, string(f.value)), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func indexOfTimestamp(values []tsm1.Value, ts int64) int {
+	for i, v := range values {
+		if v.UnixNano() == ts {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildSeriesKey joins measurement and tags, sorted by key, into the
+// "measurement,k1=v1,k2=v2" form tsm1 series keys use.
+func buildSeriesKey(measurement []byte, tags map[string]string) []byte {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.Write(measurement)
+	for _, k := range keys {
+		b.WriteByte(',')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+
+	return []byte(b.String())
+}
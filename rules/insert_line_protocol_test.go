@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oktal/infix/filter"
+	"github.com/oktal/infix/lineprotocol"
+)
+
+func TestInsertLineProtocol_ShouldBuildFromSample(t *testing.T) {
+	assertBuildFromSample(t, &InsertLineProtocolRuleConfig{})
+}
+
+func TestInsertLineProtocol_ShouldBuildFail(t *testing.T) {
+	data := []struct {
+		name string
+
+		config        string
+		expectedError error
+	}{
+		{
+			"missing files",
+			`
+				[measurement.strings]
+					equal="cpu"
+				[field.pattern]
+					pattern=".*"
+			`,
+			ErrMissingFiles,
+		},
+		{
+			"missing measurement filter",
+			`
+				files="*.lp"
+				[field.pattern]
+					pattern=".*"
+			`,
+			ErrMissingMeasurementFilter,
+		},
+		{
+			"missing field filter",
+			`
+				files="*.lp"
+				[measurement.strings]
+					equal="cpu"
+			`,
+			ErrMissingFieldFilter,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			assertBuildFromStringCallback(t, d.config, &InsertLineProtocolRuleConfig{}, func(r Rule, err error) {
+				assert.Nil(t, r)
+				assert.Equal(t, d.expectedError, err)
+			})
+		})
+	}
+}
+
+type fakeShardWriter struct {
+	written map[string][]tsm1.Value
+}
+
+func newFakeShardWriter() *fakeShardWriter {
+	return &fakeShardWriter{written: make(map[string][]tsm1.Value)}
+}
+
+func (w *fakeShardWriter) WriteValues(key []byte, values []tsm1.Value) error {
+	w.written[string(key)] = append(w.written[string(key)], values...)
+	return nil
+}
+
+func TestInsertLineProtocol_ShouldApplyAndWriteValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infix-lineprotocol")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lpFile := filepath.Join(dir, "import.lp")
+	err = ioutil.WriteFile(lpFile, []byte(
+		"cpu,host=my-host idle=12i,active=0.5 1\n"+
+			"mem,host=my-host used=2048u 1\n",
+	), 0644)
+	assert.NoError(t, err)
+
+	measurementFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "cpu"})
+	assert.NoError(t, err)
+	fieldFilter, err := filter.NewPatternFilter(".*")
+	assert.NoError(t, err)
+
+	rule := NewInsertLineProtocol(filepath.Join(dir, "*.lp"), nil, lineprotocol.Nanosecond, duplicateKeyOverwrite, measurementFilter, fieldFilter)
+
+	writer := newFakeShardWriter()
+	shard := &Shard{FieldsIndex: NewFieldsIndex(), Writer: writer}
+
+	rule.Start()
+	rule.CheckMode(false)
+	rule.StartShard(shard)
+
+	err = rule.EndShard()
+	assert.NoError(t, err)
+	rule.End()
+
+	idleKey := string(tsm1.SeriesFieldKeyBytes("cpu,host=my-host", "idle"))
+	activeKey := string(tsm1.SeriesFieldKeyBytes("cpu,host=my-host", "active"))
+	memKey := string(tsm1.SeriesFieldKeyBytes("mem,host=my-host", "used"))
+
+	assert.Equal(t, []tsm1.Value{tsm1.NewIntegerValue(1, 12)}, writer.written[idleKey])
+	assert.Equal(t, []tsm1.Value{tsm1.NewFloatValue(1, 0.5)}, writer.written[activeKey])
+	assert.Nil(t, writer.written[memKey])
+}
+
+func TestInsertLineProtocol_ShouldRejectLineWithoutTimestamp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infix-lineprotocol")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lpFile := filepath.Join(dir, "import.lp")
+	err = ioutil.WriteFile(lpFile, []byte("cpu,host=my-host idle=12i\n"), 0644)
+	assert.NoError(t, err)
+
+	measurementFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "cpu"})
+	assert.NoError(t, err)
+	fieldFilter, err := filter.NewPatternFilter(".*")
+	assert.NoError(t, err)
+
+	rule := NewInsertLineProtocol(filepath.Join(dir, "*.lp"), nil, lineprotocol.Nanosecond, duplicateKeyOverwrite, measurementFilter, fieldFilter)
+
+	writer := newFakeShardWriter()
+	shard := &Shard{FieldsIndex: NewFieldsIndex(), Writer: writer}
+
+	rule.StartShard(shard)
+
+	err = rule.EndShard()
+	assert.Equal(t, ErrMissingTimestamp, err)
+	assert.Empty(t, writer.written)
+}
+
+func TestInsertLineProtocol_ShouldMatchEscapedMeasurementAndField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infix-lineprotocol")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lpFile := filepath.Join(dir, "import.lp")
+	err = ioutil.WriteFile(lpFile, []byte(`cpu\ load,host=my-host value\=1=12i 1`+"\n"), 0644)
+	assert.NoError(t, err)
+
+	measurementFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "cpu load"})
+	assert.NoError(t, err)
+	fieldFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "value=1"})
+	assert.NoError(t, err)
+
+	rule := NewInsertLineProtocol(filepath.Join(dir, "*.lp"), nil, lineprotocol.Nanosecond, duplicateKeyOverwrite, measurementFilter, fieldFilter)
+
+	writer := newFakeShardWriter()
+	shard := &Shard{FieldsIndex: NewFieldsIndex(), Writer: writer}
+
+	rule.StartShard(shard)
+	err = rule.EndShard()
+	assert.NoError(t, err)
+
+	key := string(tsm1.SeriesFieldKeyBytes(`cpu\ load,host=my-host`, `value\=1`))
+	assert.Equal(t, []tsm1.Value{tsm1.NewIntegerValue(1, 12)}, writer.written[key])
+}
@@ -0,0 +1,115 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/tsdb/engine/tsm1"
+
+	"github.com/oktal/infix/filter"
+)
+
+// RenameFieldRuleConfig configures a rule that renames fields matched by
+// measurement and field, replacing their name with the result of
+// Replacement applied through the field filter's pattern.
+type RenameFieldRuleConfig struct {
+	Replacement string `toml:"replacement"`
+
+	Measurement filter.Filter
+	Field       filter.Filter
+}
+
+func (c *RenameFieldRuleConfig) Sample() string {
+	return `
+		replacement="agg_5m_${1}_${2}"
+		[measurement.include]
+			values=["cpu"]
+		[field.pattern]
+			pattern="^(.+)_(avg|sum)$"
+	`
+}
+
+func (c *RenameFieldRuleConfig) Build() (Rule, error) {
+	if c.Measurement == nil {
+		return nil, ErrMissingMeasurementFilter
+	}
+
+	if c.Field == nil {
+		return nil, ErrMissingFieldFilter
+	}
+
+	pattern, ok := c.Field.(*filter.PatternFilter)
+	if !ok {
+		return nil, fmt.Errorf("rules: rename_field requires a [field.pattern] filter")
+	}
+
+	return NewRenameField(c.Measurement, c.Field, func(value string) string {
+		return pattern.Pattern.ReplaceAllString(value, c.Replacement)
+	}), nil
+}
+
+// RenameField renames the fields matched by measurement and field,
+// deriving their new name by calling rename with the current one.
+type RenameField struct {
+	measurement filter.Filter
+	field       filter.Filter
+	rename      func(string) string
+
+	check bool
+	shard *Shard
+}
+
+// NewRenameField returns a RenameField rule renaming fields matched by
+// measurement and field using rename.
+func NewRenameField(measurement, field filter.Filter, rename func(string) string) *RenameField {
+	return &RenameField{
+		measurement: measurement,
+		field:       field,
+		rename:      rename,
+	}
+}
+
+func (r *RenameField) Start() {}
+
+func (r *RenameField) StartShard(shard *Shard) {
+	r.shard = shard
+}
+
+func (r *RenameField) CheckMode(check bool) {
+	r.check = check
+}
+
+func (r *RenameField) Apply(key []byte, values []tsm1.Value) ([]byte, []tsm1.Value, error) {
+	seriesKey, fieldKey := tsm1.SeriesAndFieldFromCompositeKey(key)
+	measurement, _ := models.ParseKeyBytes(seriesKey)
+
+	if !matchMeasurementAndField(r.measurement, r.field, measurement, fieldKey) {
+		return key, values, nil
+	}
+
+	unescapedFieldName := string(unescapeKey(fieldKey))
+
+	newFieldName := r.rename(unescapedFieldName)
+	if newFieldName == unescapedFieldName {
+		return key, values, nil
+	}
+
+	escapedNewFieldName := escapeKey(newFieldName)
+
+	newKey := tsm1.SeriesFieldKeyBytes(string(seriesKey), escapedNewFieldName)
+
+	if r.shard != nil && !r.check {
+		if fields := r.shard.FieldsIndex.Fields(measurement); fields != nil {
+			fields.Rename(string(fieldKey), escapedNewFieldName)
+		}
+	}
+
+	return newKey, values, nil
+}
+
+func (r *RenameField) EndShard() error {
+	r.shard = nil
+	return nil
+}
+
+func (r *RenameField) End() {}
@@ -154,3 +154,43 @@ func TestRenameField_ShouldUpdateFieldsIndex(t *testing.T) {
 		assert.Equal(t, newField.Name, string(newFieldKey))
 	}
 }
+
+func TestRenameField_ShouldMatchEscapedMeasurement(t *testing.T) {
+	measurementFilter := filter.NewIncludeFilter([]string{"cpu load"})
+	fieldFilter, err := filter.NewPatternFilter("^(.+)_(avg|sum)$")
+	assert.NoError(t, err)
+
+	rule := NewRenameField(measurementFilter, fieldFilter, func(value string) string {
+		return string(fieldFilter.Pattern.ReplaceAllString(value, "agg_5m_${1}_${2}"))
+	})
+
+	key := tsm1.SeriesFieldKeyBytes(`cpu\ load,host=my-host`, "idle_avg")
+	values := []tsm1.Value{tsm1.NewFloatValue(0, 3.5)}
+
+	expectedKey := tsm1.SeriesFieldKeyBytes(`cpu\ load,host=my-host`, "agg_5m_idle_avg")
+
+	gotKey, gotValues, err := rule.Apply(key, values)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedKey, gotKey)
+	assert.Equal(t, values, gotValues)
+}
+
+func TestRenameField_ShouldMatchAndRenameEscapedFieldName(t *testing.T) {
+	measurementFilter := filter.NewIncludeFilter([]string{"cpu"})
+	fieldFilter, err := filter.NewPatternFilter("^(.+)_(avg|sum)$")
+	assert.NoError(t, err)
+
+	rule := NewRenameField(measurementFilter, fieldFilter, func(value string) string {
+		return string(fieldFilter.Pattern.ReplaceAllString(value, "agg_5m_${1}_${2}"))
+	})
+
+	key := tsm1.SeriesFieldKeyBytes("cpu,host=my-host", `value\=1_avg`)
+	values := []tsm1.Value{tsm1.NewFloatValue(0, 3.5)}
+
+	expectedKey := tsm1.SeriesFieldKeyBytes("cpu,host=my-host", `agg_5m_value\=1_avg`)
+
+	gotKey, gotValues, err := rule.Apply(key, values)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedKey, gotKey)
+	assert.Equal(t, values, gotValues)
+}
@@ -0,0 +1,56 @@
+// Package rules implements the transformations infix can apply to the
+// tsm1.Values it reads while rewriting a shard.
+package rules
+
+import (
+	"errors"
+
+	"github.com/influxdata/influxdb/tsdb/engine/tsm1"
+)
+
+var (
+	// ErrUnknownType is returned when a rule is configured with a field
+	// type infix does not know how to convert.
+	ErrUnknownType = errors.New("rules: unknown type")
+
+	// ErrMissingMeasurementFilter is returned when a rule is configured
+	// without a [measurement] filter.
+	ErrMissingMeasurementFilter = errors.New("rules: missing measurement filter")
+
+	// ErrMissingFieldFilter is returned when a rule is configured without
+	// a [field] filter.
+	ErrMissingFieldFilter = errors.New("rules: missing field filter")
+)
+
+// Rule transforms the composite key and tsm1.Values infix reads from a
+// shard while it rewrites it.
+type Rule interface {
+	// Start is called once, before the first shard is processed.
+	Start()
+
+	// StartShard is called when infix starts rewriting shard.
+	StartShard(shard *Shard)
+
+	// CheckMode toggles dry-run mode. When check is true, a rule must
+	// report what it would do without mutating shard state.
+	CheckMode(check bool)
+
+	// Apply transforms the composite key and values read from a shard,
+	// returning the key and values to persist.
+	Apply(key []byte, values []tsm1.Value) ([]byte, []tsm1.Value, error)
+
+	// EndShard is called once a shard has been fully rewritten.
+	EndShard() error
+
+	// End is called once every shard has been processed.
+	End()
+}
+
+// RuleConfig builds a Rule from a parsed TOML configuration.
+type RuleConfig interface {
+	// Sample returns a sample TOML configuration for the rule.
+	Sample() string
+
+	// Build validates the configuration and builds the Rule it describes.
+	Build() (Rule, error)
+}
@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/naoina/toml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/influxdata/influxql"
+	"github.com/oktal/infix/filter"
+)
+
+// measurementFields describes the fields of a single measurement, used to
+// seed a test shard's fields index.
+type measurementFields struct {
+	measurement string
+	fields      map[string]influxql.DataType
+}
+
+// newTestShard builds a Shard whose FieldsIndex is pre-populated from
+// measurements.
+func newTestShard(measurements []measurementFields) *Shard {
+	idx := NewFieldsIndex()
+	for _, m := range measurements {
+		mf := idx.CreateFieldsIfNotExists(m.measurement)
+		for name, typ := range m.fields {
+			mf.CreateFieldIfNotExists(name, typ)
+		}
+	}
+
+	return &Shard{FieldsIndex: idx}
+}
+
+// assertBuildFromStringCallback parses tomlString, unmarshals it into
+// config and invokes cb with the result of building the Rule it
+// describes.
+func assertBuildFromStringCallback(t *testing.T, tomlString string, config RuleConfig, cb func(Rule, error)) {
+	t.Helper()
+
+	table, err := toml.Parse([]byte(tomlString))
+	assert.NoError(t, err)
+
+	err = filter.UnmarshalConfig(table, config)
+	assert.NoError(t, err)
+
+	r, err := config.Build()
+	cb(r, err)
+}
+
+// assertBuildFromSample asserts that config.Sample() parses and builds
+// into a valid Rule.
+func assertBuildFromSample(t *testing.T, config RuleConfig) {
+	t.Helper()
+
+	assertBuildFromStringCallback(t, config.Sample(), config, func(r Rule, err error) {
+		assert.NoError(t, err)
+		assert.NotNil(t, r)
+	})
+}
@@ -0,0 +1,14 @@
+package rules
+
+// Shard is the subset of an open shard that a Rule needs while infix
+// rewrites it: its fields index, so type or name changes a rule makes can
+// be reflected back into fields.idx alongside the rewritten TSM blocks.
+type Shard struct {
+	FieldsIndex *FieldsIndex
+
+	// Writer persists brand new tsm1.Values added by rules such as
+	// InsertLineProtocol, through the same path the rewrite engine uses
+	// for the blocks it rewrites. It may be nil for rules, such as the
+	// ones under test, that never add new points.
+	Writer ShardWriter
+}
@@ -0,0 +1,379 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/tsdb/engine/tsm1"
+	"github.com/influxdata/influxql"
+
+	"github.com/oktal/infix/filter"
+)
+
+// overflowPolicy controls what happens when a conversion involving
+// integer or unsigned values would overflow the destination type.
+type overflowPolicy int
+
+const (
+	// overflowError fails the conversion with a range error.
+	overflowError overflowPolicy = iota
+	// overflowClamp saturates the value to the destination's min or max.
+	overflowClamp
+)
+
+func overflowPolicyFromString(s string) (overflowPolicy, error) {
+	switch s {
+	case "", "error":
+		return overflowError, nil
+	case "clamp":
+		return overflowClamp, nil
+	default:
+		return overflowError, fmt.Errorf("rules: unknown onOverflow policy %q", s)
+	}
+}
+
+// UpdateFieldTypeRuleConfig configures a rule that converts the values of
+// matching fields from one type to another.
+type UpdateFieldTypeRuleConfig struct {
+	FromType string `toml:"fromType"`
+	ToType   string `toml:"toType"`
+
+	// OnOverflow selects how out-of-range integer/unsigned conversions
+	// are handled: "error" (the default) fails the rule, "clamp"
+	// saturates to the destination type's min or max.
+	OnOverflow string `toml:"onOverflow"`
+
+	Measurement filter.Filter
+	Field       filter.Filter
+}
+
+func (c *UpdateFieldTypeRuleConfig) Sample() string {
+	return `
+		fromType="integer"
+		toType="float"
+		# onOverflow="error" # or "clamp"
+		[measurement.strings]
+			equal="cpu"
+		[field.pattern]
+			pattern="^(idle|active)"
+	`
+}
+
+func (c *UpdateFieldTypeRuleConfig) Build() (Rule, error) {
+	fromType, ok := dataTypeFromString(c.FromType)
+	if !ok {
+		return nil, ErrUnknownType
+	}
+
+	toType, ok := dataTypeFromString(c.ToType)
+	if !ok {
+		return nil, ErrUnknownType
+	}
+
+	if c.Measurement == nil {
+		return nil, ErrMissingMeasurementFilter
+	}
+
+	if c.Field == nil {
+		return nil, ErrMissingFieldFilter
+	}
+
+	onOverflow, err := overflowPolicyFromString(c.OnOverflow)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := NewUpdateFieldType(c.Measurement, c.Field, fromType, toType)
+	rule.onOverflow = onOverflow
+	return rule, nil
+}
+
+func dataTypeFromString(s string) (influxql.DataType, bool) {
+	switch s {
+	case "integer":
+		return influxql.Integer, true
+	case "float":
+		return influxql.Float, true
+	case "boolean":
+		return influxql.Boolean, true
+	case "string":
+		return influxql.String, true
+	case "unsigned":
+		return influxql.Unsigned, true
+	default:
+		return influxql.Unknown, false
+	}
+}
+
+// UpdateFieldType converts the values of fields matched by measurement
+// and field from fromType to toType.
+type UpdateFieldType struct {
+	measurement filter.Filter
+	field       filter.Filter
+
+	fromType influxql.DataType
+	toType   influxql.DataType
+
+	onOverflow overflowPolicy
+
+	check bool
+	shard *Shard
+}
+
+// NewUpdateFieldType returns an UpdateFieldType rule converting values of
+// type fromType to toType for fields matched by measurement and field.
+func NewUpdateFieldType(measurement, field filter.Filter, fromType, toType influxql.DataType) *UpdateFieldType {
+	return &UpdateFieldType{
+		measurement: measurement,
+		field:       field,
+		fromType:    fromType,
+		toType:      toType,
+	}
+}
+
+func (r *UpdateFieldType) Start() {}
+
+func (r *UpdateFieldType) StartShard(shard *Shard) {
+	r.shard = shard
+}
+
+func (r *UpdateFieldType) CheckMode(check bool) {
+	r.check = check
+}
+
+func (r *UpdateFieldType) Apply(key []byte, values []tsm1.Value) ([]byte, []tsm1.Value, error) {
+	seriesKey, fieldKey := tsm1.SeriesAndFieldFromCompositeKey(key)
+	measurement, _ := models.ParseKeyBytes(seriesKey)
+
+	if !matchMeasurementAndField(r.measurement, r.field, measurement, fieldKey) {
+		return key, values, nil
+	}
+
+	converted := make([]tsm1.Value, len(values))
+	for i, v := range values {
+		cv, err := r.convert(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		converted[i] = cv
+	}
+
+	if r.shard != nil && !r.check {
+		if fields := r.shard.FieldsIndex.Fields(measurement); fields != nil {
+			fields.SetType(string(fieldKey), r.toType)
+		}
+	}
+
+	return key, converted, nil
+}
+
+func (r *UpdateFieldType) EndShard() error {
+	r.shard = nil
+	return nil
+}
+
+func (r *UpdateFieldType) End() {}
+
+func valueType(v tsm1.Value) influxql.DataType {
+	switch v.Value().(type) {
+	case int64:
+		return influxql.Integer
+	case uint64:
+		return influxql.Unsigned
+	case float64:
+		return influxql.Float
+	case bool:
+		return influxql.Boolean
+	case string:
+		return influxql.String
+	default:
+		return influxql.Unknown
+	}
+}
+
+// convert converts v to r.toType, leaving it untouched if it is not
+// already of type r.fromType.
+func (r *UpdateFieldType) convert(v tsm1.Value) (tsm1.Value, error) {
+	if valueType(v) != r.fromType {
+		return v, nil
+	}
+
+	ts := v.UnixNano()
+
+	switch r.fromType {
+	case influxql.Integer:
+		return r.convertInteger(ts, v.Value().(int64))
+	case influxql.Float:
+		return r.convertFloat(ts, v.Value().(float64))
+	case influxql.Boolean:
+		return r.convertBoolean(ts, v.Value().(bool))
+	case influxql.String:
+		return r.convertString(ts, v.Value().(string))
+	case influxql.Unsigned:
+		return r.convertUnsigned(ts, v.Value().(uint64))
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func (r *UpdateFieldType) convertInteger(ts int64, i int64) (tsm1.Value, error) {
+	switch r.toType {
+	case influxql.Integer:
+		return tsm1.NewIntegerValue(ts, i), nil
+	case influxql.Float:
+		return tsm1.NewFloatValue(ts, float64(i)), nil
+	case influxql.Boolean:
+		return tsm1.NewBooleanValue(ts, i != 0), nil
+	case influxql.String:
+		return tsm1.NewStringValue(ts, strconv.FormatInt(i, 10)), nil
+	case influxql.Unsigned:
+		u, err := r.intToUnsigned(i)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewUnsignedValue(ts, u), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func (r *UpdateFieldType) convertFloat(ts int64, f float64) (tsm1.Value, error) {
+	switch r.toType {
+	case influxql.Integer:
+		return tsm1.NewIntegerValue(ts, int64(f)), nil
+	case influxql.Float:
+		return tsm1.NewFloatValue(ts, f), nil
+	case influxql.Boolean:
+		return tsm1.NewBooleanValue(ts, f != 0), nil
+	case influxql.String:
+		return tsm1.NewStringValue(ts, strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case influxql.Unsigned:
+		u, err := r.floatToUnsigned(f)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewUnsignedValue(ts, u), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func (r *UpdateFieldType) convertBoolean(ts int64, b bool) (tsm1.Value, error) {
+	switch r.toType {
+	case influxql.Integer:
+		return tsm1.NewIntegerValue(ts, boolToInt(b)), nil
+	case influxql.Float:
+		return tsm1.NewFloatValue(ts, float64(boolToInt(b))), nil
+	case influxql.Boolean:
+		return tsm1.NewBooleanValue(ts, b), nil
+	case influxql.String:
+		return tsm1.NewStringValue(ts, strconv.FormatBool(b)), nil
+	case influxql.Unsigned:
+		return tsm1.NewUnsignedValue(ts, uint64(boolToInt(b))), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func (r *UpdateFieldType) convertString(ts int64, s string) (tsm1.Value, error) {
+	switch r.toType {
+	case influxql.Integer:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewIntegerValue(ts, i), nil
+	case influxql.Float:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewFloatValue(ts, f), nil
+	case influxql.Boolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewBooleanValue(ts, b), nil
+	case influxql.String:
+		return tsm1.NewStringValue(ts, s), nil
+	case influxql.Unsigned:
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewUnsignedValue(ts, u), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func (r *UpdateFieldType) convertUnsigned(ts int64, u uint64) (tsm1.Value, error) {
+	switch r.toType {
+	case influxql.Integer:
+		i, err := r.unsignedToInt(u)
+		if err != nil {
+			return nil, err
+		}
+		return tsm1.NewIntegerValue(ts, i), nil
+	case influxql.Float:
+		return tsm1.NewFloatValue(ts, float64(u)), nil
+	case influxql.Boolean:
+		return tsm1.NewBooleanValue(ts, u != 0), nil
+	case influxql.String:
+		return tsm1.NewStringValue(ts, strconv.FormatUint(u, 10)), nil
+	case influxql.Unsigned:
+		return tsm1.NewUnsignedValue(ts, u), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (r *UpdateFieldType) intToUnsigned(i int64) (uint64, error) {
+	if i < 0 {
+		if r.onOverflow == overflowClamp {
+			return 0, nil
+		}
+		return 0, rangeError("intToUnsigned", strconv.FormatInt(i, 10))
+	}
+	return uint64(i), nil
+}
+
+func (r *UpdateFieldType) unsignedToInt(u uint64) (int64, error) {
+	if u > math.MaxInt64 {
+		if r.onOverflow == overflowClamp {
+			return math.MaxInt64, nil
+		}
+		return 0, rangeError("unsignedToInt", strconv.FormatUint(u, 10))
+	}
+	return int64(u), nil
+}
+
+func (r *UpdateFieldType) floatToUnsigned(f float64) (uint64, error) {
+	if f < 0 {
+		if r.onOverflow == overflowClamp {
+			return 0, nil
+		}
+		return 0, rangeError("floatToUnsigned", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	if f >= math.MaxUint64 {
+		if r.onOverflow == overflowClamp {
+			return math.MaxUint64, nil
+		}
+		return 0, rangeError("floatToUnsigned", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	return uint64(f), nil
+}
+
+func rangeError(fn, num string) error {
+	return &strconv.NumError{Func: fn, Num: num, Err: strconv.ErrRange}
+}
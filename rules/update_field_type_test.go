@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"math"
 	"strconv"
 	"testing"
 
@@ -106,6 +107,10 @@ func TestUpdateFieldType_ShouldApply(t *testing.T) {
 		return tsm1.NewStringValue(ts, v)
 	}
 
+	uintVal := func(ts int64, v uint64) tsm1.Value {
+		return tsm1.NewUnsignedValue(ts, v)
+	}
+
 	toInt := func(v float64) int64 {
 		return int64(v)
 	}
@@ -395,6 +400,192 @@ func TestUpdateFieldType_ShouldApply(t *testing.T) {
 				},
 			},
 		},
+		{
+			"should convert integer to unsigned",
+			influxql.Integer,
+			influxql.Unsigned,
+			[]testData{
+				{
+					"convert integer to unsigned",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{intVal(0, 12), intVal(1, 15)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+					nil,
+				},
+				{
+					"negative integer overflows",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{intVal(0, -1)},
+
+					nil,
+					nil,
+					strconv.ErrRange,
+				},
+				{
+					"keep unsigned value",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12)},
+					nil,
+				},
+			},
+		},
+		{
+			"should convert unsigned to integer",
+			influxql.Unsigned,
+			influxql.Integer,
+			[]testData{
+				{
+					"convert unsigned to integer",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{intVal(0, 12), intVal(1, 15)},
+					nil,
+				},
+				{
+					"huge unsigned overflows",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, math.MaxUint64)},
+
+					nil,
+					nil,
+					strconv.ErrRange,
+				},
+			},
+		},
+		{
+			"should convert float to unsigned",
+			influxql.Float,
+			influxql.Unsigned,
+			[]testData{
+				{
+					"convert float to unsigned",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{floatVal(0, 12.8), floatVal(1, 15.2)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+					nil,
+				},
+				{
+					"negative float overflows",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{floatVal(0, -3.5)},
+
+					nil,
+					nil,
+					strconv.ErrRange,
+				},
+			},
+		},
+		{
+			"should convert unsigned to float",
+			influxql.Unsigned,
+			influxql.Float,
+			[]testData{
+				{
+					"convert unsigned to float",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{floatVal(0, 12), floatVal(1, 15)},
+					nil,
+				},
+			},
+		},
+		{
+			"should convert unsigned to string",
+			influxql.Unsigned,
+			influxql.String,
+			[]testData{
+				{
+					"convert unsigned to string",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{strVal(0, "12"), strVal(1, "15")},
+					nil,
+				},
+			},
+		},
+		{
+			"should convert string to unsigned",
+			influxql.String,
+			influxql.Unsigned,
+			[]testData{
+				{
+					"convert string to unsigned",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{strVal(0, "12"), strVal(1, "15")},
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 12), uintVal(1, 15)},
+					nil,
+				},
+				{
+					"conversion error",
+
+					key("disk_free.gauge", "value"),
+					[]tsm1.Value{strVal(0, "-1")},
+
+					nil,
+					nil,
+					strconv.ErrSyntax,
+				},
+			},
+		},
+		{
+			"should convert boolean to unsigned",
+			influxql.Boolean,
+			influxql.Unsigned,
+			[]testData{
+				{
+					"convert boolean to unsigned",
+
+					key("node_up.gauge", "value"),
+					[]tsm1.Value{boolVal(0, false), boolVal(1, true)},
+
+					key("node_up.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 0), uintVal(1, 1)},
+					nil,
+				},
+			},
+		},
+		{
+			"should convert unsigned to boolean",
+			influxql.Unsigned,
+			influxql.Boolean,
+			[]testData{
+				{
+					"convert unsigned to boolean",
+
+					key("node_up.gauge", "value"),
+					[]tsm1.Value{uintVal(0, 0), uintVal(1, 1)},
+
+					key("node_up.gauge", "value"),
+					[]tsm1.Value{boolVal(0, false), boolVal(1, true)},
+					nil,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -532,3 +723,36 @@ func TestUpdateFieldType_ShouldUpdateFieldsIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateFieldType_ShouldMatchEscapedMeasurementAndField(t *testing.T) {
+	measurementFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "cpu load.gauge"})
+	assert.NoError(t, err)
+	fieldFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: "value=1"})
+	assert.NoError(t, err)
+
+	rule := NewUpdateFieldType(measurementFilter, fieldFilter, influxql.Integer, influxql.Float)
+
+	key := tsm1.SeriesFieldKeyBytes(`cpu\ load.gauge`, `value\=1`)
+	values := []tsm1.Value{tsm1.NewIntegerValue(0, 12)}
+
+	gotKey, gotValues, err := rule.Apply(key, values)
+	assert.NoError(t, err)
+	assert.Equal(t, key, gotKey)
+	assert.Equal(t, []tsm1.Value{tsm1.NewFloatValue(0, 12)}, gotValues)
+}
+
+func TestUpdateFieldType_ShouldMatchRawEscapedBytesWhenConfigured(t *testing.T) {
+	measurementFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: `cpu\ load.gauge`, RawBytes: true})
+	assert.NoError(t, err)
+	fieldFilter, err := filter.NewStringFilter(&filter.StringFilterConfig{Equal: `value\=1`, RawBytes: true})
+	assert.NoError(t, err)
+
+	rule := NewUpdateFieldType(measurementFilter, fieldFilter, influxql.Integer, influxql.Float)
+
+	key := tsm1.SeriesFieldKeyBytes(`cpu\ load.gauge`, `value\=1`)
+	values := []tsm1.Value{tsm1.NewIntegerValue(0, 12)}
+
+	_, gotValues, err := rule.Apply(key, values)
+	assert.NoError(t, err)
+	assert.Equal(t, []tsm1.Value{tsm1.NewFloatValue(0, 12)}, gotValues)
+}